@@ -0,0 +1,64 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// pollInterval is how often Entry re-checks History while waiting for
+// a round's signature to be produced.
+const pollInterval = 100 * time.Millisecond
+
+// thresholdBeacon adapts the existing in-committee BLS threshold
+// RandomBeacon to the BeaconAPI interface, so it can sit in a
+// BeaconNetworks alongside externally driven sources (e.g. drand)
+// without Node or Chain needing to special-case it.
+type thresholdBeacon struct {
+	rb *RandomBeacon
+}
+
+// NewThresholdBeaconAPI wraps rb so it can be used as a BeaconAPI.
+func NewThresholdBeaconAPI(rb *RandomBeacon) BeaconAPI {
+	return &thresholdBeacon{rb: rb}
+}
+
+func (t *thresholdBeacon) Entry(ctx context.Context, round uint64) (BeaconEntry, error) {
+	for {
+		history := t.rb.History()
+		if uint64(len(history)) > round {
+			return BeaconEntry{Round: round, Sig: history[round].Sig}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return BeaconEntry{}, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (t *thresholdBeacon) VerifyEntry(cur, prev BeaconEntry) error {
+	if len(cur.Sig) == 0 {
+		return fmt.Errorf("empty beacon signature for round %d", cur.Round)
+	}
+
+	expect := SHA3(prev.Sig)
+	if !verifyRandBeaconSig(cur.Sig, cur.Round, expect) {
+		return fmt.Errorf("invalid beacon signature for round %d", cur.Round)
+	}
+
+	return nil
+}
+
+func (t *thresholdBeacon) LatestRound() uint64 {
+	history := t.rb.History()
+	if len(history) == 0 {
+		return 0
+	}
+	return uint64(len(history)) - 1
+}
+
+func (t *thresholdBeacon) Committees(round uint64) (rb, bp, nt int) {
+	return t.rb.Committees(round)
+}
@@ -0,0 +1,108 @@
+// Package chainmaker provides a deterministic chain-building test
+// harness for this module's round/beacon consensus model, analogous
+// to go-ethereum's chain_makers: unit tests for Chain, Node and
+// RandomBeacon can generate a sequence of valid, signed blocks
+// in-process instead of spinning up a real network.
+package chainmaker
+
+import (
+	"fmt"
+
+	"github.com/helinwang/dex/pkg/consensus"
+)
+
+// Config configures chain generation. NotaryGroup is the
+// BLS-threshold notary committee active at genesis; later blocks may
+// switch committees via BlockGen.RotateNotaryGroup.
+type Config struct {
+	consensus.Config
+	ProposerSK  consensus.SK
+	NotaryGroup consensus.TestNotaryGroup
+}
+
+// BlockGen represents a single block being generated by GenerateChain.
+// The gen function passed to GenerateChain calls its methods to
+// script the round number, forced beacon randomness, transactions and
+// notary-group/DKG events for that block.
+type BlockGen struct {
+	i          int
+	round      uint64
+	beaconSig  []byte
+	txns       [][]byte
+	nextGroup  *consensus.TestNotaryGroup
+	dkgGroupID *int
+}
+
+// SetRound overrides the round number for this block; GenerateChain
+// otherwise assigns rounds sequentially starting at 1.
+func (b *BlockGen) SetRound(round uint64) { b.round = round }
+
+// ForceBeaconEntry writes sig as the random beacon signature for this
+// block's round, simulating a beacon network boundary (see
+// consensus.BeaconNetworks). Blocks without it leave the chain's
+// random beacon history as it is.
+func (b *BlockGen) ForceBeaconEntry(sig []byte) { b.beaconSig = sig }
+
+// AddTxn appends a transaction to the block being generated.
+func (b *BlockGen) AddTxn(t []byte) { b.txns = append(b.txns, t) }
+
+// RotateNotaryGroup switches the notary group used to notarize blocks
+// from this block onward to one backed by members, with a majority
+// threshold.
+func (b *BlockGen) RotateNotaryGroup(members []consensus.SK) {
+	b.nextGroup = &consensus.TestNotaryGroup{
+		GroupID:   b.i + 1,
+		Shares:    members,
+		Threshold: len(members)/2 + 1,
+	}
+}
+
+// TriggerDKG simulates groupID running a distributed key generation
+// round while producing this block. The chainmaker harness does not
+// run a real DKG protocol; it records the event so a gen callback can
+// assert on RotateNotaryGroup having been driven by it, via
+// DKGTriggered.
+func (b *BlockGen) TriggerDKG(groupID int) {
+	b.dkgGroupID = &groupID
+}
+
+// DKGTriggered reports whether TriggerDKG was called for this block,
+// and if so, the group ID it was called with.
+func (b *BlockGen) DKGTriggered() (groupID int, ok bool) {
+	if b.dkgGroupID == nil {
+		return 0, false
+	}
+	return *b.dkgGroupID, true
+}
+
+// GenerateChain builds n blocks on top of genesis/state, calling gen
+// once per block to script its round, beacon, transactions and notary
+// rotation. It returns the resulting chain of blocks, or an error if
+// any block fails to generate.
+func GenerateChain(genesis *consensus.Block, state consensus.State, cfg Config, n int, gen func(i int, b *BlockGen)) ([]*consensus.Block, error) {
+	chain := consensus.NewChain(genesis, state, consensus.Rand(consensus.SHA3([]byte("chainmaker"))), cfg.Config, nil, nil)
+
+	group := cfg.NotaryGroup
+	round := uint64(1)
+	blocks := make([]*consensus.Block, 0, n)
+	for i := 0; i < n; i++ {
+		b := &BlockGen{i: i, round: round}
+		if gen != nil {
+			gen(i, b)
+		}
+
+		if b.nextGroup != nil {
+			group = *b.nextGroup
+		}
+
+		block, _, _, err := consensus.GenerateTestBlock(chain, cfg.ProposerSK, b.round, group, b.txns, b.beaconSig)
+		if err != nil {
+			return nil, fmt.Errorf("chainmaker: generate block %d (round %d) failed: %v", i, b.round, err)
+		}
+
+		blocks = append(blocks, block)
+		round = b.round + 1
+	}
+
+	return blocks, nil
+}
@@ -0,0 +1,125 @@
+package consensus
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testCredentials() NodeCredentials {
+	return NodeCredentials{
+		SK:          RandSK(),
+		Groups:      []int{1, 2},
+		GroupShares: []SK{RandSK(), RandSK()},
+	}
+}
+
+func TestCredentialRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credential")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "node.cred")
+	want := testCredentials()
+	passphrase := []byte("correct horse battery staple")
+
+	if err := SaveCredential(path, want, passphrase); err != nil {
+		t.Fatalf("SaveCredential failed: %v", err)
+	}
+
+	got, err := LoadCredential(path, passphrase)
+	if err != nil {
+		t.Fatalf("LoadCredential failed: %v", err)
+	}
+
+	assert.Equal(t, want, got)
+}
+
+func TestCredentialWrongPassphrase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credential")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "node.cred")
+	if err := SaveCredential(path, testCredentials(), []byte("right")); err != nil {
+		t.Fatalf("SaveCredential failed: %v", err)
+	}
+
+	_, err = LoadCredential(path, []byte("wrong"))
+	assert.Error(t, err)
+}
+
+func TestCredentialTamperDetection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credential")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "node.cred")
+	passphrase := []byte("correct horse battery staple")
+	if err := SaveCredential(path, testCredentials(), passphrase); err != nil {
+		t.Fatalf("SaveCredential failed: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b[len(b)-1] ^= 0xff
+	if err := ioutil.WriteFile(path, b, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LoadCredential(path, passphrase)
+	assert.Error(t, err)
+}
+
+func TestMigrateCredentialFromLegacyPlaintext(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credential")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "node.cred")
+	want := testCredentials()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	passphrase := []byte("correct horse battery staple")
+	if err := MigrateCredential(path, passphrase); err != nil {
+		t.Fatalf("MigrateCredential failed: %v", err)
+	}
+
+	got, err := LoadCredential(path, passphrase)
+	if err != nil {
+		t.Fatalf("LoadCredential after migration failed: %v", err)
+	}
+	assert.Equal(t, want, got)
+
+	// Migrating again is a no-op, not a double-encryption.
+	if err := MigrateCredential(path, passphrase); err != nil {
+		t.Fatalf("second MigrateCredential failed: %v", err)
+	}
+	got2, err := LoadCredential(path, passphrase)
+	if err != nil {
+		t.Fatalf("LoadCredential after second migration failed: %v", err)
+	}
+	assert.Equal(t, want, got2)
+}
@@ -0,0 +1,67 @@
+// Package credstore resolves the passphrase used to decrypt a node's
+// credentials: from an environment variable, a file, or an
+// interactive terminal prompt.
+package credstore
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// FromEnv returns the passphrase held in the named environment
+// variable, or ok=false if it is unset.
+func FromEnv(name string) (passphrase []byte, ok bool) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, false
+	}
+	return []byte(v), true
+}
+
+// FromFile reads the passphrase from path, trimming a single trailing
+// newline if present.
+func FromFile(path string) ([]byte, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("credstore: read passphrase file failed: %v", err)
+	}
+	return []byte(strings.TrimSuffix(string(b), "\n")), nil
+}
+
+// Prompt asks for the passphrase on the controlling terminal without
+// echoing it. If stdin is not a terminal (e.g. piped input in tests
+// or scripts), it falls back to reading a single line.
+func Prompt(prompt string) ([]byte, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	if terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return terminal.ReadPassword(int(os.Stdin.Fd()))
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("credstore: read passphrase failed: %v", err)
+	}
+	return []byte(strings.TrimSuffix(line, "\n")), nil
+}
+
+// Resolve returns the passphrase from the envVar environment variable
+// if set, else from fallbackFile if non-empty, else by prompting
+// interactively.
+func Resolve(envVar, fallbackFile string) ([]byte, error) {
+	if p, ok := FromEnv(envVar); ok {
+		return p, nil
+	}
+
+	if fallbackFile != "" {
+		return FromFile(fallbackFile)
+	}
+
+	return Prompt("node credential passphrase: ")
+}
@@ -0,0 +1,152 @@
+package consensus
+
+import "sync"
+
+// Topic identifies a class of event published on an EventBus.
+type Topic string
+
+// Topics published by the consensus layer, so external subscribers - a
+// JSON-RPC/WebSocket API, a metrics exporter, an audit logger, a
+// wallet - can observe consensus progress without patching consensus
+// internals. Node is the only publisher: it publishes round.start,
+// round.end, block.proposed, block.notarized and oracle.elected, and
+// still calls Networking directly alongside publishing. Nothing in
+// this package publishes beacon.entry or txn.accepted.
+const (
+	TopicRoundStart Topic = "round.start"
+	TopicRoundEnd   Topic = "round.end"
+	// TopicBlockProposed events carry a *BlockProposal.
+	TopicBlockProposed Topic = "block.proposed"
+	// TopicBlockNotarized events carry the notarized *Block itself,
+	// not just its round, and are only published when a round
+	// actually produces one.
+	TopicBlockNotarized Topic = "block.notarized"
+	TopicBeaconEntry    Topic = "beacon.entry"
+	TopicTxnAccepted    Topic = "txn.accepted"
+	TopicOracleElected  Topic = "oracle.elected"
+)
+
+// Event is a single message published on an EventBus.
+type Event struct {
+	Topic Topic
+	Data  interface{}
+}
+
+// Subscription is returned by EventBus.Subscribe and
+// EventBus.SubscribeAsync.
+type Subscription struct {
+	bus   *EventBus
+	topic Topic
+	id    uint64
+	ch    chan Event
+}
+
+// Unsubscribe stops the subscription from receiving further events.
+func (s *Subscription) Unsubscribe() {
+	s.bus.unsubscribe(s)
+}
+
+// C returns the channel an async subscription receives events on. It
+// is nil for subscriptions created with Subscribe.
+func (s *Subscription) C() <-chan Event {
+	return s.ch
+}
+
+type subscriber struct {
+	cb func(Event)
+	ch chan Event
+}
+
+// EventBus is a synchronous-by-default publish/subscribe hub for
+// consensus events (round boundaries, proposals, notarizations,
+// beacon entries, accepted transactions).
+//
+// Publish does not return until every synchronous subscriber's
+// callback (registered with Subscribe) has run, on the publishing
+// goroutine, preserving today's in-process call-order semantics.
+// Subscribers registered with SubscribeAsync instead receive events on
+// a bounded channel: when that channel is full, Publish drops the
+// oldest queued event to make room rather than blocking, so a slow
+// subscriber can never stall consensus.
+type EventBus struct {
+	mu     sync.RWMutex
+	nextID uint64
+	subs   map[Topic]map[uint64]*subscriber
+}
+
+// NewEventBus creates an empty EventBus.
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[Topic]map[uint64]*subscriber)}
+}
+
+// Subscribe registers cb to run synchronously for every event
+// published to topic.
+func (b *EventBus) Subscribe(topic Topic, cb func(Event)) *Subscription {
+	return b.subscribe(topic, &subscriber{cb: cb})
+}
+
+// SubscribeAsync registers a subscriber that receives events to topic
+// on its own channel, buffered to size.
+func (b *EventBus) SubscribeAsync(topic Topic, size int) *Subscription {
+	return b.subscribe(topic, &subscriber{ch: make(chan Event, size)})
+}
+
+func (b *EventBus) subscribe(topic Topic, s *subscriber) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	if b.subs[topic] == nil {
+		b.subs[topic] = make(map[uint64]*subscriber)
+	}
+	b.subs[topic][id] = s
+
+	return &Subscription{bus: b, topic: topic, id: id, ch: s.ch}
+}
+
+func (b *EventBus) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs[sub.topic], sub.id)
+}
+
+// Publish delivers Event{Topic: topic, Data: data} to every current
+// subscriber of topic.
+func (b *EventBus) Publish(topic Topic, data interface{}) {
+	b.mu.RLock()
+	subs := make([]*subscriber, 0, len(b.subs[topic]))
+	for _, s := range b.subs[topic] {
+		subs = append(subs, s)
+	}
+	b.mu.RUnlock()
+
+	ev := Event{Topic: topic, Data: data}
+	for _, s := range subs {
+		if s.cb != nil {
+			s.cb(ev)
+			continue
+		}
+		publishAsync(s.ch, ev)
+	}
+}
+
+// publishAsync delivers ev to ch, dropping the oldest queued event
+// first if ch is full.
+func publishAsync(ch chan Event, ev Event) {
+	select {
+	case ch <- ev:
+		return
+	default:
+	}
+
+	select {
+	case <-ch:
+	default:
+	}
+
+	select {
+	case ch <- ev:
+	default:
+	}
+}
@@ -0,0 +1,50 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBusSyncSubscriberSeesEveryEvent(t *testing.T) {
+	bus := NewEventBus()
+
+	var rounds []uint64
+	bus.Subscribe(TopicRoundStart, func(e Event) {
+		rounds = append(rounds, e.Data.(uint64))
+	})
+
+	for i := uint64(0); i < 50; i++ {
+		bus.Publish(TopicRoundStart, i)
+	}
+
+	assert.Len(t, rounds, 50)
+	assert.Equal(t, uint64(0), rounds[0])
+	assert.Equal(t, uint64(49), rounds[49])
+}
+
+func TestEventBusAsyncSubscriberDropsOldestWhenFull(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.SubscribeAsync(TopicBlockNotarized, 2)
+
+	bus.Publish(TopicBlockNotarized, 1)
+	bus.Publish(TopicBlockNotarized, 2)
+	bus.Publish(TopicBlockNotarized, 3)
+
+	first := <-sub.C()
+	second := <-sub.C()
+	assert.Equal(t, 2, first.Data)
+	assert.Equal(t, 3, second.Data)
+}
+
+func TestEventBusUnsubscribeStopsDelivery(t *testing.T) {
+	bus := NewEventBus()
+
+	count := 0
+	sub := bus.Subscribe(TopicRoundEnd, func(Event) { count++ })
+	bus.Publish(TopicRoundEnd, nil)
+	sub.Unsubscribe()
+	bus.Publish(TopicRoundEnd, nil)
+
+	assert.Equal(t, 1, count)
+}
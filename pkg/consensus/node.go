@@ -1,11 +1,8 @@
 package consensus
 
 import (
-	"bytes"
 	"context"
-	"encoding/gob"
-	"fmt"
-	"io/ioutil"
+	"strconv"
 	"sync"
 	"time"
 
@@ -19,15 +16,22 @@ import (
 // Nodes form a group randomly, the randomness comes from the random
 // beacon.
 type Node struct {
-	addr  Addr
-	cfg   Config
-	sk    SK
-	net   *Networking
-	chain *Chain
+	addr    Addr
+	cfg     Config
+	sk      SK
+	net     *Networking
+	chain   *Chain
+	beacons BeaconNetworks
+	nodeSet *NodeSet
+	events  *EventBus
+
+	oracleSources    map[string]OracleSource
+	oracleDispatcher OracleDispatcher
 
 	mu sync.Mutex
 	// the memberships of different groups
 	memberships    []membership
+	notarySet      *NotarySet
 	notarizeChs    []chan *BlockProposal
 	cancelNotarize func()
 }
@@ -37,11 +41,32 @@ type NodeCredentials struct {
 	SK          SK
 	Groups      []int
 	GroupShares []SK
+	// GroupMemberIdx[i] is this node's 1-based rank within the DKG
+	// that produced GroupShares[i] - the canonical share ID every
+	// member of Groups[i] agrees on. It is needed to combine a
+	// threshold of the group's partial signatures (oracle shares,
+	// notary shares, beacon shares) via Lagrange interpolation. It
+	// may be shorter than Groups/GroupShares for credentials saved
+	// before this field existed; memberships built from a missing
+	// entry get the zero bls.ID and cannot take part in threshold
+	// recovery until re-provisioned.
+	GroupMemberIdx []int
 }
 
 type membership struct {
 	skShare bls.SecretKey
 	groupID int
+	id      bls.ID
+}
+
+// memberIDFromIdx converts a DKG participant's 1-based rank within a
+// group into the bls.ID that rank corresponds to.
+func memberIDFromIdx(idx int) bls.ID {
+	var id bls.ID
+	if err := id.SetDecString(strconv.Itoa(idx)); err != nil {
+		panic(err)
+	}
+	return id
 }
 
 // Config is the consensus layer configuration.
@@ -51,54 +76,183 @@ type Config struct {
 	GroupThreshold int
 }
 
-// NewNode creates a new node.
-func NewNode(chain *Chain, sk SK, net *Networking, cfg Config) *Node {
+// NewNode creates a new node. beacons is the sequence of random
+// beacon sources the node draws per-round randomness from; if nil,
+// the node falls back to chain's own internal threshold beacon for
+// every round. nodeSet is the full validator population used for
+// gossip, transaction routing and block relay; it is independent of
+// which notary-sets (the per-round BLS-threshold subsets) this node
+// holds a share in, which is tracked separately in memberships.
+// events is the bus the node publishes round and block lifecycle
+// events to; if nil, a private EventBus with no subscribers is
+// created so callers that don't care about events can pass nil.
+// oracleSources maps an OracleTask's SourceID to the OracleSource the
+// elected oracle committee fetches from; it may be nil if the chain
+// schedules no oracle tasks. oracleDispatcher is whatever owns oracle
+// task scheduling (typically a *dex.Dispatcher); it may be nil, in
+// which case the node still logs and publishes TopicOracleElected when
+// elected to an oracle committee but fetches, signs and embeds
+// nothing.
+func NewNode(chain *Chain, sk SK, net *Networking, cfg Config, beacons BeaconNetworks, nodeSet *NodeSet, events *EventBus, oracleSources map[string]OracleSource, oracleDispatcher OracleDispatcher) *Node {
 	pk, err := sk.PK()
 	if err != nil {
 		panic(err)
 	}
 
+	if beacons == nil {
+		beacons = BeaconNetworks{{StartRound: 0, Beacon: NewThresholdBeaconAPI(chain.RandomBeacon)}}
+	}
+
+	if events == nil {
+		events = NewEventBus()
+	}
+
 	addr := pk.Addr()
 	n := &Node{
-		addr:  addr,
-		cfg:   cfg,
-		sk:    sk,
-		chain: chain,
-		net:   net,
+		addr:             addr,
+		cfg:              cfg,
+		sk:               sk,
+		chain:            chain,
+		net:              net,
+		beacons:          beacons,
+		nodeSet:          nodeSet,
+		events:           events,
+		oracleSources:    oracleSources,
+		oracleDispatcher: oracleDispatcher,
 	}
 	chain.n = n
 	return n
 }
 
+// Events returns the node's EventBus, for external subscribers (a
+// JSON-RPC/WebSocket API, a metrics exporter, an audit logger, a
+// wallet) that want to observe consensus progress without patching
+// consensus internals.
+func (n *Node) Events() *EventBus {
+	return n.events
+}
+
+// InNotarySet reports whether the node currently holds a share in the
+// notary-set that notarizes the round most recently started with
+// _StartRound. A node can be in the chain's NodeSet (and so still
+// relay proposals, verify notarizations and update chain state) while
+// answering false here, e.g. because notary-set rotation excluded it
+// for this round.
+func (n *Node) InNotarySet() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.notarySet.Contains(n.addr)
+}
+
 // Chain returns node's block chain.
 func (n *Node) Chain() *Chain {
 	return n.chain
 }
 
+// AddMembership installs skShare as the node's share in groupID, e.g.
+// once the node completes a DKG round for a newly formed group. It
+// replaces any existing share for groupID.
+func (n *Node) AddMembership(groupID int, skShare bls.SecretKey) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for i, m := range n.memberships {
+		if m.groupID == groupID {
+			n.memberships[i].skShare = skShare
+			return
+		}
+	}
+	n.memberships = append(n.memberships, membership{groupID: groupID, skShare: skShare})
+}
+
+// RetireMembership removes the node's share in groupID, e.g. once
+// groupID's duties are permanently handed off to a successor group
+// formed by a later DKG round. Unlike notarySet, which _StartRound and
+// EndRound rebuild every round, memberships are long-lived
+// credential-derived shares that outlive any single round, so they
+// must be retired explicitly rather than torn down automatically: a
+// node legitimately holding no duty for several consecutive rounds (a
+// live rotation, see TestNotaryRotationExcludesLocalNodeButChainStaysLive)
+// is not the same as groupID having been retired.
+func (n *Node) RetireMembership(groupID int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	kept := n.memberships[:0]
+	for _, m := range n.memberships {
+		if m.groupID != groupID {
+			kept = append(kept, m)
+		}
+	}
+	n.memberships = kept
+}
+
 // Start starts the p2p network service.
 func (n *Node) Start(myAddr, seedAddr string) {
 	n.net.Start(myAddr, seedAddr)
 }
 
+// pendingEvent is an event recorded while n.mu is held and published
+// once it is released, so a synchronous EventBus subscriber can call
+// back into the node (e.g. InNotarySet) without deadlocking on n.mu.
+type pendingEvent struct {
+	topic Topic
+	data  interface{}
+}
+
 // StartRound marks the start of the given round. It happens when the
 // random beacon signature for the given round is received.
 func (n *Node) _StartRound(round uint64) {
 	n.mu.Lock()
-	defer n.mu.Unlock()
 
 	log.Debug("start round", "round", round, "addr", n.addr)
+	pending := []pendingEvent{{TopicRoundStart, round}}
 
 	var ntCancelCtx context.Context
-	_, bp, nt := n.chain.RandomBeacon.Committees(round)
+	beacon := n.beacons.BeaconNetworkForRound(round).Beacon
+	entry, err := beacon.Entry(context.Background(), round)
+	if err != nil {
+		n.mu.Unlock()
+		log.Error("fetch beacon entry failed", "round", round, "addr", n.addr, "err", err)
+		return
+	}
+
+	var bp, nt int
+	if tb, ok := beacon.(thresholdBeaconDuty); ok {
+		_, bp, nt = tb.Committees(round)
+	} else {
+		_, bp, nt, _ = CommitteesForEntry(entry, n.chain.NumGroups())
+	}
+
+	// oc (the oracle committee) is always derived straight from the
+	// entry: unlike bp/nt it has no legacy RandomBeacon.Committees
+	// equivalent to defer to, so every BeaconAPI elects it the same
+	// way.
+	_, _, _, oc := CommitteesForEntry(entry, n.chain.NumGroups())
+
+	n.notarySet = NewNotarySet(nt, n.chain.GroupMembers(nt))
+	if !n.notarySet.Contains(n.addr) {
+		// Node is in the chain's node-set but not this round's
+		// notary-set: it still relays proposals and notarized
+		// blocks through Networking and keeps chain state up to
+		// date, it just holds no share to notarize with.
+		log.Debug("not in notary-set this round, relaying only", "round", round, "addr", n.addr)
+	}
+
 	for _, m := range n.memberships {
 		if m.groupID == bp {
 			bp := n.chain.ProposeBlock(n.sk)
+			pending = append(pending, pendingEvent{TopicBlockProposed, bp})
 			go func() {
 				log.Debug("proposing block", "addr", n.addr, "round", bp.Round, "hash", bp.Hash())
 				n.net.recvBlockProposal(n.net.myself, bp)
 			}()
 		}
 
+		if m.groupID == oc {
+			go n.runOracleTasks(round, m.id, m.skShare)
+		}
+
 		if m.groupID == nt {
 			if ntCancelCtx == nil {
 				ntCancelCtx, n.cancelNotarize = context.WithCancel(context.Background())
@@ -118,41 +272,65 @@ func (n *Node) _StartRound(round uint64) {
 			}()
 		}
 	}
+
+	n.mu.Unlock()
+
+	for _, e := range pending {
+		n.events.Publish(e.topic, e.data)
+	}
 }
 
-// EndRound marks the end of the given round. It happens when the
-// block for the given round is received.
-func (n *Node) EndRound(round uint64) {
+// EndRound marks the end of the given round. It is called once the
+// round's outcome is known: block is the notarized block if one was
+// produced, or nil if the round timed out with no notarization (e.g.
+// the proposer or enough notaries failed to respond in time).
+func (n *Node) EndRound(round uint64, block *Block) {
 	n.mu.Lock()
-	defer n.mu.Unlock()
 
-	log.Debug("end round", "round", round, "addr", n.addr)
+	log.Debug("end round", "round", round, "addr", n.addr, "notarized", block != nil)
 
+	// Tear down this round's notarization state unconditionally,
+	// whether or not the node was a notary-set member, so agreement
+	// state never leaks across a rotation boundary.
 	n.notarizeChs = nil
 	if n.cancelNotarize != nil {
 		n.cancelNotarize()
+		n.cancelNotarize = nil
 	}
+	n.notarySet = nil
 
-	rb, _, _ := n.chain.RandomBeacon.Committees(round)
-	for _, m := range n.memberships {
-		if m.groupID != rb {
-			continue
+	beacon := n.beacons.BeaconNetworkForRound(round).Beacon
+	if tb, ok := beacon.(thresholdBeaconDuty); ok {
+		rb, _, _ := tb.Committees(round)
+		for _, m := range n.memberships {
+			if m.groupID != rb {
+				continue
+			}
+			// Current node is a member of the random
+			// beacon committee, members collatively
+			// produce the random beacon signature using
+			// BLS threshold signature scheme. There are
+			// multiple committees, which committee will
+			// produce the next random beacon signature is
+			// derived from the current random beacon
+			// signature.
+			keyShare := m.skShare
+			go func() {
+				history := n.chain.RandomBeacon.History()
+				lastSigHash := SHA3(history[round].Sig)
+				s := signRandBeaconShare(n.sk.MustGet(), keyShare, round+1, lastSigHash)
+				n.net.recvRandBeaconSigShare(n.net.myself, s)
+			}()
 		}
-		// Current node is a member of the random
-		// beacon committee, members collatively
-		// produce the random beacon signature using
-		// BLS threshold signature scheme. There are
-		// multiple committees, which committee will
-		// produce the next random beacon signature is
-		// derived from the current random beacon
-		// signature.
-		keyShare := m.skShare
-		go func() {
-			history := n.chain.RandomBeacon.History()
-			lastSigHash := SHA3(history[round].Sig)
-			s := signRandBeaconShare(n.sk.MustGet(), keyShare, round+1, lastSigHash)
-			n.net.recvRandBeaconSigShare(n.net.myself, s)
-		}()
+	}
+	// else: beacon is driven externally (e.g. drand); this node has
+	// no signing duty for round+1.
+
+	n.mu.Unlock()
+
+	n.events.Publish(TopicRoundEnd, round)
+	if block != nil {
+		n.events.Publish(TopicBlockNotarized, block)
 	}
 }
 
@@ -171,12 +349,20 @@ func (n *Node) SendTxn(t []byte) {
 	n.net.RecvTxn(t)
 }
 
-// MakeNode makes a new node with the given configurations.
-func MakeNode(credentials NodeCredentials, net Network, cfg Config, genesis *Block, state State, txnPool TxnPool, u Updater) *Node {
+// MakeNode makes a new node with the given configurations. beacons
+// may be nil, in which case the node uses its own internal threshold
+// beacon for every round; pass a configured BeaconNetworks (e.g. one
+// backed by drand.Beacon) to source randomness externally instead.
+// nodeSet is the chain's full validator population; a node's notary-
+// set memberships (which rotate per round) are derived separately
+// from credentials.Groups and do not need to cover every nodeSet
+// member. events may be nil; see NewNode. oracleSources and
+// oracleDispatcher may be nil; see NewNode.
+func MakeNode(credentials NodeCredentials, net Network, cfg Config, genesis *Block, state State, txnPool TxnPool, u Updater, beacons BeaconNetworks, nodeSet *NodeSet, events *EventBus, oracleSources map[string]OracleSource, oracleDispatcher OracleDispatcher) *Node {
 	randSeed := Rand(SHA3([]byte("dex")))
 	chain := NewChain(genesis, state, randSeed, cfg, txnPool, u)
 	networking := NewNetworking(net, chain)
-	node := NewNode(chain, credentials.SK, networking, cfg)
+	node := NewNode(chain, credentials.SK, networking, cfg, beacons, nodeSet, events, oracleSources, oracleDispatcher)
 	for j := range credentials.Groups {
 		share, err := credentials.GroupShares[j].Get()
 		if err != nil {
@@ -184,25 +370,11 @@ func MakeNode(credentials NodeCredentials, net Network, cfg Config, genesis *Blo
 		}
 
 		m := membership{groupID: credentials.Groups[j], skShare: share}
+		if j < len(credentials.GroupMemberIdx) {
+			m.id = memberIDFromIdx(credentials.GroupMemberIdx[j])
+		}
 		node.memberships = append(node.memberships, m)
 	}
 	node.chain.RandomBeacon.n = node
 	return node
 }
-
-// LoadCredential loads node credential from disk.
-func LoadCredential(path string) (NodeCredentials, error) {
-	var c NodeCredentials
-	b, err := ioutil.ReadFile(path)
-	if err != nil {
-		return c, fmt.Errorf("open credential file failed: %v", err)
-	}
-
-	dec := gob.NewDecoder(bytes.NewReader(b))
-	err = dec.Decode(&c)
-	if err != nil {
-		return c, fmt.Errorf("decode credential file failed: %v", err)
-	}
-
-	return c, nil
-}
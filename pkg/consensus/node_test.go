@@ -0,0 +1,54 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestNodeEndRoundPublishesExactlyOneNotarizationPerBlock drives a
+// Node through 50 rounds of real (chainmaker-style) block generation,
+// mixing in a round that times out with no notarized block, and
+// checks a TopicBlockNotarized subscriber sees exactly one event per
+// round that actually produced a block - carrying that block - and
+// none for the round that didn't.
+func TestNodeEndRoundPublishesExactlyOneNotarizationPerBlock(t *testing.T) {
+	cfg := Config{GroupSize: 5, GroupThreshold: 3}
+	chain := NewChain(nil, nil, Rand(SHA3([]byte("node-test"))), cfg, nil, nil)
+
+	group := TestNotaryGroup{Threshold: 3}
+	for i := 0; i < 5; i++ {
+		group.Shares = append(group.Shares, RandSK())
+	}
+	proposerSK := RandSK()
+
+	events := NewEventBus()
+	var notarized []*Block
+	events.Subscribe(TopicBlockNotarized, func(e Event) {
+		notarized = append(notarized, e.Data.(*Block))
+	})
+
+	var roundEnds int
+	events.Subscribe(TopicRoundEnd, func(Event) { roundEnds++ })
+
+	node := NewNode(chain, proposerSK, nil, cfg, nil, nil, events, nil, nil)
+
+	const timedOutRound = 25
+	var blocks []*Block
+	for round := uint64(1); round <= 50; round++ {
+		if round == timedOutRound {
+			node.EndRound(round, nil)
+			continue
+		}
+
+		block, _, _, err := GenerateTestBlock(chain, proposerSK, round, group, nil, nil)
+		if err != nil {
+			t.Fatalf("GenerateTestBlock for round %d failed: %v", round, err)
+		}
+		blocks = append(blocks, block)
+		node.EndRound(round, block)
+	}
+
+	assert.Equal(t, 50, roundEnds, "TopicRoundEnd should fire once per round, notarized or not")
+	assert.Equal(t, blocks, notarized, "TopicBlockNotarized should fire exactly once per notarized block, in order, and not for the timed-out round")
+}
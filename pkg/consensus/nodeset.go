@@ -0,0 +1,74 @@
+package consensus
+
+// NodeSet is the full validator population of the chain: every node
+// eligible for gossip, transaction routing and block relay. Unlike a
+// NotarySet, membership in the NodeSet does not imply the node holds
+// a BLS share in any particular round's committees.
+type NodeSet struct {
+	members map[Addr]PK
+}
+
+// NewNodeSet builds a NodeSet from the given node public keys.
+func NewNodeSet(members []PK) *NodeSet {
+	s := &NodeSet{members: make(map[Addr]PK, len(members))}
+	for _, pk := range members {
+		s.members[pk.Addr()] = pk
+	}
+	return s
+}
+
+// Contains reports whether addr is part of the node-set.
+func (s *NodeSet) Contains(addr Addr) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.members[addr]
+	return ok
+}
+
+// Addrs returns the addresses of every member, in no particular
+// order.
+func (s *NodeSet) Addrs() []Addr {
+	addrs := make([]Addr, 0, len(s.members))
+	for addr := range s.members {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
+// NotarySet is the BLS-threshold subset of a NodeSet that notarizes
+// blocks for a single round. Which nodes make up the notary set
+// rotates every round, selected by the random beacon, and is always a
+// subset of the chain's NodeSet: a node can be in the node-set but
+// outside the current notary-set, in which case it still relays
+// proposals and verifies notarizations, it just does not hold a share
+// to sign with this round.
+type NotarySet struct {
+	groupID int
+	members map[Addr]bool
+}
+
+// NewNotarySet builds the NotarySet for groupID from addrs.
+func NewNotarySet(groupID int, addrs []Addr) *NotarySet {
+	s := &NotarySet{groupID: groupID, members: make(map[Addr]bool, len(addrs))}
+	for _, addr := range addrs {
+		s.members[addr] = true
+	}
+	return s
+}
+
+// GroupID returns the BLS group backing this notary set.
+func (s *NotarySet) GroupID() int {
+	if s == nil {
+		return -1
+	}
+	return s.groupID
+}
+
+// Contains reports whether addr holds a share in this notary set.
+func (s *NotarySet) Contains(addr Addr) bool {
+	if s == nil {
+		return false
+	}
+	return s.members[addr]
+}
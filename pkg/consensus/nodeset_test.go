@@ -0,0 +1,82 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeSetContains(t *testing.T) {
+	pk0 := RandSK().MustPK()
+	pk1 := RandSK().MustPK()
+	other := RandSK().MustPK()
+
+	s := NewNodeSet([]PK{pk0, pk1})
+	assert.True(t, s.Contains(pk0.Addr()))
+	assert.True(t, s.Contains(pk1.Addr()))
+	assert.False(t, s.Contains(other.Addr()))
+}
+
+func TestNotarySetIsSubsetOfNodeSet(t *testing.T) {
+	pk0 := RandSK().MustPK()
+	pk1 := RandSK().MustPK()
+	outside := RandSK().MustPK()
+
+	nodes := NewNodeSet([]PK{pk0, pk1, outside})
+	notaries := NewNotarySet(7, []Addr{pk0.Addr(), pk1.Addr()})
+
+	assert.Equal(t, 7, notaries.GroupID())
+	assert.True(t, notaries.Contains(pk0.Addr()))
+	assert.True(t, nodes.Contains(pk0.Addr()))
+
+	// outside is in the node-set (it relays and verifies) but holds
+	// no share in this notary-set.
+	assert.True(t, nodes.Contains(outside.Addr()))
+	assert.False(t, notaries.Contains(outside.Addr()))
+}
+
+func TestNilNotarySetContainsNothing(t *testing.T) {
+	var s *NotarySet
+	assert.False(t, s.Contains(RandSK().MustPK().Addr()))
+	assert.Equal(t, -1, s.GroupID())
+}
+
+// TestNotaryRotationExcludesLocalNodeButChainStaysLive drives a real
+// Node through several rounds notarized entirely by other groups the
+// local node holds no membership in, and checks it stays live as a
+// relay-only participant: _StartRound never puts it in the round's
+// notary-set or starts a notarize goroutine for it, yet every round
+// still produces a block.
+func TestNotaryRotationExcludesLocalNodeButChainStaysLive(t *testing.T) {
+	localSK := RandSK()
+
+	otherShares := make([]SK, 5)
+	for i := range otherShares {
+		otherShares[i] = RandSK()
+	}
+	group := TestNotaryGroup{Shares: otherShares, Threshold: 3}
+
+	proposerSK := RandSK()
+	cfg := Config{GroupSize: 5, GroupThreshold: 3}
+	chain := NewChain(nil, nil, Rand(SHA3([]byte("rotation-liveness"))), cfg, nil, nil)
+
+	// node holds no memberships at all: it is never part of whatever
+	// group chain.RandomBeacon.Committees elects as nt, so it is
+	// always the excluded case this test is about.
+	node := NewNode(chain, localSK, nil, cfg, nil, nil, NewEventBus(), nil, nil)
+
+	const rounds = 5
+	for round := uint64(1); round <= rounds; round++ {
+		block, _, _, err := GenerateTestBlock(chain, proposerSK, round, group, nil, nil)
+		if err != nil {
+			t.Fatalf("round %d: chain stalled despite local node's exclusion: %v", round, err)
+		}
+		assert.NotNil(t, block, "round %d should still notarize without the local node", round)
+
+		node._StartRound(round)
+		assert.False(t, node.InNotarySet(), "round %d: local node has no membership and must stay excluded", round)
+		assert.Empty(t, node.notarizeChs, "round %d: excluded node must not start notarizing", round)
+
+		node.EndRound(round, block)
+	}
+}
@@ -0,0 +1,112 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/helinwang/log15"
+
+	"github.com/dfinity/go-dfinity-crypto/bls"
+)
+
+// OracleEvent is a single piece of external-chain data observed by an
+// OracleSource.
+type OracleEvent struct {
+	SourceID string
+	Data     []byte
+}
+
+// OracleSource is implemented by every external data source a node
+// can be configured to pull oracle data from (e.g. Ethereum logs,
+// Solana account state). The OracleSources map passed to MakeNode
+// selects which source an oracle task's SourceID resolves to; the
+// task format itself (query semantics, target round, result
+// aggregation) is defined by the dex package, which is the only
+// consumer of this interface that knows how tasks are scheduled.
+type OracleSource interface {
+	// Subscribe streams events matching filter until ctx is
+	// canceled or the source errors, after which the channel is
+	// closed.
+	Subscribe(ctx context.Context, filter []byte) <-chan OracleEvent
+}
+
+// OracleDispatcher is implemented by whatever owns OracleTask
+// scheduling (the dex package): given this node's ID and share in the
+// elected oracle committee for round, it fetches from the configured
+// sources, threshold-signs the result, and embeds it in the block
+// under construction for round. Node calls it from runOracleTasks;
+// consensus has no opinion on task scheduling or embedding beyond
+// this interface.
+type OracleDispatcher interface {
+	DispatchOracleTasks(ctx context.Context, round uint64, id bls.ID, skShare bls.SecretKey, sources map[string]OracleSource) error
+}
+
+// OracleShare is one committee member's partial BLS signature over an
+// oracle task's fetched data - the oracle-committee analogue of
+// NtShare for block notarization. ID is the member's canonical DKG
+// share ID within the committee (see membership.id), needed to
+// recover the full threshold signature via Lagrange interpolation in
+// CombineOracleShares.
+type OracleShare struct {
+	ID  bls.ID
+	Sig bls.Sign
+}
+
+// SignOracleShare produces this node's OracleShare: a partial BLS
+// signature over an oracle task's fetched data, domain-separated by
+// round so a share can never be replayed as a beacon or notary share.
+// It is only one committee member's share; combining a threshold of
+// them into the group's full signature is CombineOracleShares's job.
+func SignOracleShare(id bls.ID, skShare bls.SecretKey, round uint64, data []byte) OracleShare {
+	msg := SHA3(append([]byte(fmt.Sprintf("oracle:%d:", round)), data...))
+	return OracleShare{ID: id, Sig: *skShare.Sign(string(msg))}
+}
+
+// CombineOracleShares recovers the oracle committee's full threshold
+// signature over the message its members signed from a threshold of
+// their OracleShares, via the same Lagrange-interpolation recovery a
+// notary group uses to combine NtShares into a block notarization. It
+// errors if fewer than threshold shares are given.
+func CombineOracleShares(shares []OracleShare, threshold int) ([]byte, error) {
+	if len(shares) < threshold {
+		return nil, fmt.Errorf("consensus: need %d oracle shares to recover the committee signature, got %d", threshold, len(shares))
+	}
+
+	ids := make([]bls.ID, threshold)
+	sigs := make([]bls.Sign, threshold)
+	for i := 0; i < threshold; i++ {
+		ids[i] = shares[i].ID
+		sigs[i] = shares[i].Sig
+	}
+
+	var recovered bls.Sign
+	if err := recovered.Recover(sigs, ids); err != nil {
+		return nil, fmt.Errorf("consensus: recover oracle committee signature failed: %v", err)
+	}
+
+	return recovered.Serialize(), nil
+}
+
+// runOracleTasks dispatches to the elected oracle committee member's
+// share for round, alongside the proposer/notary work _StartRound
+// already does: it fetches the round's tasks from their configured
+// OracleSource, threshold-signs the result, and embeds it in the
+// block via n.oracleDispatcher, which the dex package provides. It is
+// only called once _StartRound has determined this node's membership
+// was elected to round's oracle committee.
+func (n *Node) runOracleTasks(round uint64, id bls.ID, skShare bls.SecretKey) {
+	log.Debug("elected to oracle committee", "round", round, "addr", n.addr)
+	n.events.Publish(TopicOracleElected, round)
+
+	if n.oracleDispatcher == nil {
+		return
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(n.cfg.BlockTime))
+	defer cancel()
+
+	if err := n.oracleDispatcher.DispatchOracleTasks(ctx, round, id, skShare, n.oracleSources); err != nil {
+		log.Error("dispatch oracle tasks failed", "round", round, "addr", n.addr, "err", err)
+	}
+}
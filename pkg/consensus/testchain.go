@@ -0,0 +1,96 @@
+package consensus
+
+import "fmt"
+
+// SignShareForTest signs bp with notary's key share and returns the
+// resulting NtShare directly, bypassing the asynchronous Notarize
+// protocol (which drives the share through a channel and a callback
+// over a context-bound deadline). It exists for test harnesses that
+// need a deterministic, synchronous signing step.
+func (nt *Notary) SignShareForTest(bp *BlockProposal) (*NtShare, error) {
+	return nt.signShare(bp)
+}
+
+// ForceEntryForTest overwrites (or appends, if round is beyond the
+// current history) the beacon entry for round with sig, without
+// running the BLS threshold signing protocol. It exists for test
+// harnesses that need to simulate specific beacon outputs, e.g. a
+// BeaconNetworks migration boundary.
+func (rb *RandomBeacon) ForceEntryForTest(round uint64, sig []byte) error {
+	history := rb.History()
+	if round < uint64(len(history)) {
+		history[round].Sig = sig
+		return nil
+	}
+
+	if round != uint64(len(history)) {
+		return fmt.Errorf("round %d is not contiguous with history length %d", round, len(history))
+	}
+
+	return rb.appendEntryForTest(BeaconEntry{Round: round, Sig: sig})
+}
+
+// TestNotaryGroup is an in-memory BLS-threshold notary group used by
+// test harnesses (see consensus/chainmaker) to notarize blocks
+// without a real network: Shares holds every member's secret key
+// share and Threshold is how many of them are needed to produce a
+// valid group signature.
+type TestNotaryGroup struct {
+	GroupID   int
+	Shares    []SK
+	Threshold int
+}
+
+// GenerateTestBlock proposes a block for round on top of chain's
+// current head using proposerSK, notarizes it with group (an
+// in-memory BLS-threshold committee), and returns the resulting
+// Block together with the BlockProposal and NtShares produced along
+// the way so a caller can inspect or replay the exact sequence of
+// consensus messages. If beaconSig is non-nil it is written as the
+// round's random beacon signature via ForceEntryForTest, letting a
+// caller simulate a beacon source migration; if it is nil the random
+// beacon history is left untouched.
+func GenerateTestBlock(chain *Chain, proposerSK SK, round uint64, group TestNotaryGroup, txns [][]byte, beaconSig []byte) (*Block, *BlockProposal, []*NtShare, error) {
+	if beaconSig != nil {
+		if err := chain.RandomBeacon.ForceEntryForTest(round, beaconSig); err != nil {
+			return nil, nil, nil, fmt.Errorf("chainmaker: force beacon entry for round %d failed: %v", round, err)
+		}
+	}
+
+	bp := chain.ProposeBlock(proposerSK)
+	bp.Round = round
+	for _, t := range txns {
+		bp.Txns = append(bp.Txns, t)
+	}
+
+	addr, err := proposerSK.PK()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("chainmaker: proposer pk failed: %v", err)
+	}
+
+	shares := make([]*NtShare, 0, group.Threshold)
+	for i, sk := range group.Shares {
+		share, err := sk.Get()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("chainmaker: get group share %d failed: %v", i, err)
+		}
+
+		notary := NewNotary(addr.Addr(), proposerSK.MustGet(), share, chain)
+		s, err := notary.SignShareForTest(bp)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("chainmaker: sign notary share %d failed: %v", i, err)
+		}
+
+		shares = append(shares, s)
+		if len(shares) == group.Threshold {
+			break
+		}
+	}
+
+	block, err := chain.Finalize(bp, shares)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("chainmaker: finalize block for round %d failed: %v", round, err)
+	}
+
+	return block, bp, shares, nil
+}
@@ -0,0 +1,93 @@
+// Package ethsource implements a consensus.OracleSource backed by
+// Ethereum log subscriptions, letting an OracleTask pull data from
+// Ethereum as part of block production.
+package ethsource
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+
+	ethereum "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+
+	"github.com/helinwang/dex/pkg/consensus"
+)
+
+// Source subscribes to Ethereum contract logs via an ethclient and
+// republishes them as consensus.OracleEvent.
+type Source struct {
+	client *ethclient.Client
+}
+
+// New creates a Source backed by the Ethereum node at rawurl.
+func New(rawurl string) (*Source, error) {
+	client, err := ethclient.Dial(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("ethsource: dial %q failed: %v", rawurl, err)
+	}
+
+	return &Source{client: client}, nil
+}
+
+// Subscribe implements consensus.OracleSource. filter is the gob
+// encoding of an ethereum.FilterQuery.
+func (s *Source) Subscribe(ctx context.Context, filter []byte) <-chan consensus.OracleEvent {
+	out := make(chan consensus.OracleEvent)
+
+	go func() {
+		defer close(out)
+
+		query, err := decodeFilterQuery(filter)
+		if err != nil {
+			return
+		}
+
+		logs := make(chan types.Log)
+		sub, err := s.client.SubscribeFilterLogs(ctx, query, logs)
+		if err != nil {
+			return
+		}
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-sub.Err():
+				_ = err
+				return
+			case l := <-logs:
+				data, err := encodeLog(l)
+				if err != nil {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case out <- consensus.OracleEvent{SourceID: "ethereum", Data: data}:
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+var _ ethereum.LogFilterer = (*ethclient.Client)(nil)
+
+func decodeFilterQuery(b []byte) (ethereum.FilterQuery, error) {
+	var q ethereum.FilterQuery
+	err := gob.NewDecoder(bytes.NewReader(b)).Decode(&q)
+	return q, err
+}
+
+func encodeLog(l types.Log) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(l); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
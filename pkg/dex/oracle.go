@@ -0,0 +1,127 @@
+package dex
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dfinity/go-dfinity-crypto/bls"
+
+	"github.com/helinwang/dex/pkg/consensus"
+)
+
+// OracleTask asks the elected oracle committee to pull data from an
+// external chain as part of producing the block for TargetRound. The
+// committee is derived from the random beacon entry for TargetRound
+// the same way notary groups are; see OracleResult for how its answer
+// is signed.
+type OracleTask struct {
+	SourceID    string
+	Query       []byte
+	TargetRound uint64
+}
+
+// OracleResult is the committee's signed answer to an OracleTask,
+// embedded in the block for Task.TargetRound. When Dispatcher.Collect
+// is configured, Sig is a genuine threshold-aggregate BLS signature
+// over Data, recovered via consensus.CombineOracleShares from a
+// quorum of committee members' OracleShares - the same way a notary
+// group's NtShares combine into a block notarization - and light
+// clients can verify it against the committee's group public key
+// without re-querying SourceID themselves. Without Collect, Sig is
+// only the fetching node's own partial share, and is not yet verifiable
+// without trusting that single node.
+type OracleResult struct {
+	Task OracleTask
+	Data []byte
+	Sig  []byte
+}
+
+// oracleEventMatchesQuery reports whether ev is relevant to task,
+// i.e. it came from task's source. Subclasses of matching beyond the
+// source id (e.g. decoding and filtering on ev.Data) are the
+// responsibility of the OracleSource implementation's Subscribe
+// filter, not this check.
+func oracleEventMatchesQuery(task OracleTask, ev consensus.OracleEvent) bool {
+	return ev.SourceID == task.SourceID
+}
+
+// OracleTaskPool is asked for the tasks scheduled against a round, the
+// same way a TxnPool is asked for a round's pending transactions.
+type OracleTaskPool interface {
+	OracleTasksForRound(round uint64) []OracleTask
+}
+
+// Dispatcher implements consensus.OracleDispatcher: it resolves a
+// round's OracleTasks from a pool, fetches the first matching event
+// from each task's source, signs its own share of the result, and
+// hands the resulting OracleResult to Embed for inclusion in the
+// block under construction.
+type Dispatcher struct {
+	Pool  OracleTaskPool
+	Embed func(OracleResult)
+	// Collect gathers a threshold of the oracle committee's shares
+	// for (round, task, data) - e.g. by gossiping this node's own
+	// share over the network and waiting for others' - the oracle
+	// analogue of Notary's NtShare collection. mine is this node's
+	// own share; Collect's returned slice should include it. Collect
+	// may be nil, in which case DispatchOracleTasks embeds only
+	// mine and OracleResult.Sig is not a threshold aggregate.
+	Collect func(ctx context.Context, round uint64, task OracleTask, mine consensus.OracleShare) ([]consensus.OracleShare, error)
+	// Threshold is how many OracleShares CombineOracleShares needs to
+	// recover the committee's signature; only consulted when Collect
+	// is set.
+	Threshold int
+}
+
+// DispatchOracleTasks implements consensus.OracleDispatcher.
+func (d *Dispatcher) DispatchOracleTasks(ctx context.Context, round uint64, id bls.ID, skShare bls.SecretKey, sources map[string]consensus.OracleSource) error {
+	for _, task := range d.Pool.OracleTasksForRound(round) {
+		src, ok := sources[task.SourceID]
+		if !ok {
+			return fmt.Errorf("dex: no OracleSource configured for %q", task.SourceID)
+		}
+
+		data, err := fetchOracleData(ctx, src, task)
+		if err != nil {
+			return fmt.Errorf("dex: fetch oracle data for %q failed: %v", task.SourceID, err)
+		}
+
+		mine := consensus.SignOracleShare(id, skShare, round, data)
+		sig := mine.Sig.Serialize()
+		if d.Collect != nil {
+			shares, err := d.Collect(ctx, round, task, mine)
+			if err != nil {
+				return fmt.Errorf("dex: collect oracle shares for %q failed: %v", task.SourceID, err)
+			}
+
+			sig, err = consensus.CombineOracleShares(shares, d.Threshold)
+			if err != nil {
+				return fmt.Errorf("dex: combine oracle shares for %q failed: %v", task.SourceID, err)
+			}
+		}
+
+		d.Embed(OracleResult{Task: task, Data: data, Sig: sig})
+	}
+
+	return nil
+}
+
+// fetchOracleData subscribes to src with task's query and returns the
+// data of the first event matching task, or an error if ctx is
+// canceled first.
+func fetchOracleData(ctx context.Context, src consensus.OracleSource, task OracleTask) ([]byte, error) {
+	events := src.Subscribe(ctx, task.Query)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case ev, ok := <-events:
+			if !ok {
+				return nil, fmt.Errorf("source closed before a matching event arrived")
+			}
+			if oracleEventMatchesQuery(task, ev) {
+				return ev.Data, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package solsource implements a consensus.OracleSource backed by a
+// Solana RPC websocket subscription, letting an OracleTask pull
+// account state from Solana as part of block production.
+package solsource
+
+import (
+	"context"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/gagliardetto/solana-go/rpc/ws"
+
+	"github.com/helinwang/dex/pkg/consensus"
+)
+
+// Source subscribes to Solana account updates via an RPC websocket
+// client and republishes them as consensus.OracleEvent.
+type Source struct {
+	wsURL string
+}
+
+// New creates a Source backed by the Solana RPC websocket at wsURL.
+func New(wsURL string) *Source {
+	return &Source{wsURL: wsURL}
+}
+
+// Subscribe implements consensus.OracleSource. filter is the base58
+// encoding of the Solana account public key to watch.
+func (s *Source) Subscribe(ctx context.Context, filter []byte) <-chan consensus.OracleEvent {
+	out := make(chan consensus.OracleEvent)
+
+	go func() {
+		defer close(out)
+
+		pubkey, err := solana.PublicKeyFromBase58(string(filter))
+		if err != nil {
+			return
+		}
+
+		client, err := ws.Connect(ctx, s.wsURL)
+		if err != nil {
+			return
+		}
+		defer client.Close()
+
+		sub, err := client.AccountSubscribe(pubkey, "")
+		if err != nil {
+			return
+		}
+		defer sub.Unsubscribe()
+
+		for {
+			got, err := sub.Recv()
+			if err != nil {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case out <- consensus.OracleEvent{
+				SourceID: "solana",
+				Data:     got.Value.Account.Data.GetBinary(),
+			}:
+			}
+		}
+	}()
+
+	return out
+}